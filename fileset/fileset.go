@@ -0,0 +1,189 @@
+// Package fileset computes and persists a content-hash snapshot of a
+// working tree, so callers can tell which tracked files actually changed
+// without shelling out to `git status` - slow once the tree gets large,
+// since status stats every untracked file too.
+package fileset
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileEntry describes one file as seen by a walk of the tree.
+type FileEntry struct {
+	Path  string    `json:"path"` // relative to the walked root
+	Size  int64     `json:"size"`
+	Mtime time.Time `json:"mtime"`
+	Sha1  string    `json:"sha1"` // git blob sha1
+}
+
+// IgnoreFunc reports whether a root-relative path should be skipped.
+// It is called for both directories and files; returning true for a
+// directory skips its entire subtree.
+type IgnoreFunc func(relPath string) bool
+
+// Walk walks root, honoring ignore, and returns one FileEntry per regular
+// file, sorted by path.
+func Walk(root string, ignore IgnoreFunc) ([]FileEntry, error) {
+	var entries []FileEntry
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if ignore != nil && ignore(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore != nil && ignore(rel) {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil // symlinks, fifos, sockets, devices
+		}
+		sha, err := BlobSha1(path)
+		if err != nil {
+			return nil // file vanished or became unreadable mid-walk; skip it
+		}
+		entries = append(entries, FileEntry{
+			Path: rel, Size: info.Size(), Mtime: info.ModTime(), Sha1: sha,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// WalkPaths stats each of paths (root-relative) and returns one FileEntry
+// per path that still exists. If prev already has an entry for a path whose
+// size and mtime are unchanged, that entry's Sha1 is reused instead of
+// re-reading and re-hashing the file's content - the same "racily clean"
+// shortcut git itself relies on to make status checks on large trees cheap.
+// Pass a nil or empty prev to force every path to be re-hashed.
+func WalkPaths(root string, paths []string, prev Snapshot) ([]FileEntry, error) {
+	entries := make([]FileEntry, 0, len(paths))
+	for _, rel := range paths {
+		info, err := os.Stat(filepath.Join(root, rel))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if p, ok := prev[rel]; ok && p.Size == info.Size() && p.Mtime.Equal(info.ModTime()) {
+			entries = append(entries, p)
+			continue
+		}
+		sha, err := BlobSha1(filepath.Join(root, rel))
+		if err != nil {
+			continue // file vanished or became unreadable mid-walk; skip it
+		}
+		entries = append(entries, FileEntry{
+			Path: rel, Size: info.Size(), Mtime: info.ModTime(), Sha1: sha,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// BlobSha1 computes the sha1 git would assign a blob with this file's
+// content: sha1("blob <size>\0<content>").
+func BlobSha1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", info.Size())
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Snapshot is a saved fileset, keyed by path for O(1) lookups during Diff.
+type Snapshot map[string]FileEntry
+
+// Save serializes entries to path as JSON.
+func Save(path string, entries []FileEntry) error {
+	snap := make(Snapshot, len(entries))
+	for _, e := range entries {
+		snap[e.Path] = e
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Load reads a snapshot previously written by Save. A missing file yields
+// an empty Snapshot rather than an error, since "no snapshot yet" is a
+// normal starting state.
+func Load(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Diff reports paths added, removed, or modified (by content) between a
+// saved snapshot and a fresh walk.
+type Diff struct {
+	Added, Removed, Modified []string
+}
+
+// Diff compares the snapshot against a fresh list of entries.
+func (s Snapshot) Diff(entries []FileEntry) Diff {
+	var d Diff
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		seen[e.Path] = true
+		prev, ok := s[e.Path]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, e.Path)
+		case prev.Sha1 != e.Sha1:
+			d.Modified = append(d.Modified, e.Path)
+		}
+	}
+	for path := range s {
+		if !seen[path] {
+			d.Removed = append(d.Removed, path)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Modified)
+	return d
+}
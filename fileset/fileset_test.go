@@ -0,0 +1,97 @@
+package fileset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalkSkipsIgnoredDirs(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "keep.txt"), "keep")
+	mustWrite(t, filepath.Join(root, "skip-me", "nested.txt"), "nested")
+
+	entries, err := Walk(root, func(rel string) bool { return rel == "skip-me" })
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != "keep.txt" {
+		t.Fatalf("entries = %+v, want only keep.txt", entries)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "a.txt"), "hello")
+
+	entries, err := Walk(root, nil)
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	snapPath := filepath.Join(root, "snapshot.json")
+	if err := Save(snapPath, entries); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	snap, err := Load(snapPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(snap) != 1 || snap["a.txt"].Sha1 != entries[0].Sha1 {
+		t.Fatalf("snap = %+v, want entry for a.txt with matching sha1", snap)
+	}
+}
+
+func TestLoadMissingFileReturnsEmptySnapshot(t *testing.T) {
+	snap, err := Load(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(snap) != 0 {
+		t.Fatalf("snap = %+v, want empty", snap)
+	}
+}
+
+func TestDiffReportsAddedModifiedRemoved(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "stable.txt"), "same")
+	mustWrite(t, filepath.Join(root, "gone.txt"), "bye")
+	before, err := Walk(root, nil)
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	snap := make(Snapshot, len(before))
+	for _, e := range before {
+		snap[e.Path] = e
+	}
+
+	if err := os.Remove(filepath.Join(root, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, filepath.Join(root, "stable.txt"), "changed")
+	mustWrite(t, filepath.Join(root, "new.txt"), "new")
+
+	after, err := Walk(root, nil)
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	d := snap.Diff(after)
+	if len(d.Added) != 1 || d.Added[0] != "new.txt" {
+		t.Errorf("Added = %v, want [new.txt]", d.Added)
+	}
+	if len(d.Modified) != 1 || d.Modified[0] != "stable.txt" {
+		t.Errorf("Modified = %v, want [stable.txt]", d.Modified)
+	}
+	if len(d.Removed) != 1 || d.Removed[0] != "gone.txt" {
+		t.Errorf("Removed = %v, want [gone.txt]", d.Removed)
+	}
+}
+
+func mustWrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package gitcmd builds argv slices for invoking the underlying git binary
+// safely. It exists because naively splicing strings (e.g.
+// strings.Fields(baseGit+" add")) breaks the moment $HOME contains a space,
+// a tracked path contains spaces or newlines, or a commit message has
+// whitespace in it. Cmd never joins or re-splits a string: every argument is
+// appended to argv directly.
+package gitcmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Cmd describes one invocation of git, split into the pieces the CLI cares
+// about: global flags (--git-dir/--work-tree), the subcommand, the
+// subcommand's own flags, and anything that belongs after "--" (paths -
+// never parsed as a flag; git reads them as pathspecs).
+type Cmd struct {
+	GlobalFlags []string // e.g. "--git-dir=...", "--work-tree=..."
+	Subcommand  string   // e.g. "add", "commit", "status" - caller's responsibility to pick a safe one
+	Flags       []string // subcommand flags, e.g. "-m"
+	PostSepArgs []string // everything after "--": user paths (pathspecs)
+}
+
+// New returns a Cmd scoped to the given git-dir/work-tree pair, ready to
+// have a subcommand attached.
+func New(gitDir, workTree string) *Cmd {
+	return &Cmd{
+		GlobalFlags: []string{"--git-dir=" + gitDir, "--work-tree=" + workTree},
+	}
+}
+
+// WithSubcommand sets the git subcommand to run.
+func (c *Cmd) WithSubcommand(subcommand string) *Cmd {
+	c.Subcommand = subcommand
+	return c
+}
+
+// WithFlags appends subcommand flags (e.g. "--force", "--message=..."). Avoid
+// a flag that takes a separate value (e.g. bare "-m") when that value is
+// caller-controlled text: Build's "--" only shields PostSepArgs, so a flag
+// like "-m" immediately followed by "--" never receives the intended value -
+// git treats "--" itself as the value and turns the real message into a
+// stray pathspec. Prefer the "--flag=value" single-token form instead.
+func (c *Cmd) WithFlags(flags ...string) *Cmd {
+	c.Flags = append(c.Flags, flags...)
+	return c
+}
+
+// WithPostSepArgs appends arguments that belong after "--": paths, or
+// anything else git should take as a pathspec and never as a flag.
+func (c *Cmd) WithPostSepArgs(args ...string) *Cmd {
+	c.PostSepArgs = append(c.PostSepArgs, args...)
+	return c
+}
+
+// Build assembles the final argv and returns a ready-to-run *exec.Cmd. It
+// refuses arguments containing a NUL byte, since those would silently
+// truncate when handed to exec.
+func (c *Cmd) Build() (*exec.Cmd, error) {
+	if c.Subcommand == "" {
+		return nil, fmt.Errorf("gitcmd: subcommand is required")
+	}
+	argv := make([]string, 0, len(c.GlobalFlags)+1+len(c.Flags)+1+len(c.PostSepArgs))
+	argv = append(argv, c.GlobalFlags...)
+	argv = append(argv, c.Subcommand)
+	argv = append(argv, c.Flags...)
+	if len(c.PostSepArgs) > 0 {
+		argv = append(argv, "--")
+		argv = append(argv, c.PostSepArgs...)
+	}
+	for _, arg := range argv {
+		if strings.ContainsRune(arg, 0) {
+			return nil, fmt.Errorf("gitcmd: argument contains NUL byte: %q", arg)
+		}
+	}
+	return exec.Command("git", argv...), nil
+}
@@ -0,0 +1,132 @@
+package gitcmd
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildPreservesSpaces(t *testing.T) {
+	cmd, err := New("/home/a b/.config/sdf", "/home/a b").
+		WithSubcommand("add").
+		WithPostSepArgs("some file.txt", "another one.txt").
+		Build()
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	want := []string{
+		"git",
+		"--git-dir=/home/a b/.config/sdf",
+		"--work-tree=/home/a b",
+		"add", "--",
+		"some file.txt", "another one.txt",
+	}
+	if got := cmd.Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args = %q, want %q", got, want)
+	}
+}
+
+// TestCommitMessageActuallyCommits execs the built *exec.Cmd against a real
+// scratch repo, rather than only comparing cmd.Args: a bare "-m" followed by
+// PostSepArgs looks right as argv but fails against real git, since "--"
+// satisfies "-m"'s value and the message becomes a stray pathspec. Build a
+// commit the way runSnapshot does, via "--message=...", and confirm the
+// message with quotes and spaces round-trips through a real commit.
+func TestCommitMessageActuallyCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init")
+	run("config", "user.email", "t@example.com")
+	run("config", "user.name", "t")
+	if err := os.WriteFile(dir+"/tracked.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "tracked.txt")
+
+	const msg = `hello "world"`
+	cmd, err := New(dir+"/.git", dir).
+		WithSubcommand("commit").
+		WithFlags("--message=" + msg).
+		Build()
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+
+	logCmd := exec.Command("git", "-C", dir, "log", "-1", "--pretty=%B")
+	out, err := logCmd.Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != msg {
+		t.Errorf("commit message = %q, want %q", got, msg)
+	}
+}
+
+// TestBuildKeepsBareMFlagBeforeSeparator documents, at the Cmd-builder level,
+// why a bare "-m" must never be paired with PostSepArgs: Build does not
+// reject it, and its "--" satisfies "-m"'s own value, so the real message
+// ends up as a pathspec instead. This is an argv shape WithFlags's doc
+// comment now warns callers away from.
+func TestBuildKeepsBareMFlagBeforeSeparator(t *testing.T) {
+	cmd, err := New("/gitdir", "/worktree").
+		WithSubcommand("commit").
+		WithFlags("-m").
+		WithPostSepArgs(`hello "world"`).
+		Build()
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	want := []string{
+		"git", "--git-dir=/gitdir", "--work-tree=/worktree",
+		"commit", "-m", "--", `hello "world"`,
+	}
+	if got := cmd.Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args = %q, want %q", got, want)
+	}
+	if cmd.Args[len(cmd.Args)-2] != "--" {
+		t.Fatalf("Args = %q, expected \"--\" to immediately follow \"-m\", which is exactly the broken shape", cmd.Args)
+	}
+}
+
+func TestBuildWithoutPostSepArgsOmitsSeparator(t *testing.T) {
+	cmd, err := New("/gitdir", "/worktree").WithSubcommand("status").Build()
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	for _, arg := range cmd.Args {
+		if arg == "--" {
+			t.Errorf("Args = %q, did not expect a bare \"--\" with no post-sep args", cmd.Args)
+		}
+	}
+}
+
+func TestBuildRequiresSubcommand(t *testing.T) {
+	if _, err := New("/gitdir", "/worktree").Build(); err == nil {
+		t.Error("Build() with no subcommand: got nil error, want one")
+	}
+}
+
+func TestBuildRejectsNUL(t *testing.T) {
+	_, err := New("/gitdir", "/worktree").
+		WithSubcommand("add").
+		WithPostSepArgs("evil\x00path").
+		Build()
+	if err == nil {
+		t.Error("Build() with a NUL byte in an argument: got nil error, want one")
+	}
+}
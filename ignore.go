@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRules is a pragmatic (not fully spec-compliant) .gitignore matcher:
+// good enough to keep the trace stager from tripping over build output
+// and other noise, without pulling in a full gitignore parser.
+type ignoreRules struct {
+	patterns []string
+}
+
+// loadIgnoreRules reads $HOME/.gitignore, if present.
+func loadIgnoreRules() ignoreRules {
+	return ignoreRules{patterns: loadPatterns(userPath + "/.gitignore")}
+}
+
+// loadCombinedIgnoreRules reads both $HOME/.gitignore and the sdf-specific
+// $HOME/.sdfignore, for trace's stager, which filters tracer-reported paths
+// before prompting to add them.
+func loadCombinedIgnoreRules() ignoreRules {
+	return ignoreRules{
+		patterns: append(loadPatterns(userPath+"/.gitignore"), loadPatterns(userPath+"/.sdfignore")...),
+	}
+}
+
+func loadPatterns(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimPrefix(strings.TrimSuffix(line, "/"), "/"))
+	}
+	return patterns
+}
+
+// matches reports whether the $HOME-relative path rel is ignored.
+func (r ignoreRules) matches(rel string) bool {
+	base := filepath.Base(rel)
+	for _, pattern := range r.patterns {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+		if strings.Contains(rel, "/"+pattern+"/") || strings.HasPrefix(rel, pattern+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -2,30 +2,38 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/tabulon-ext/sdf/gitcmd"
 )
 
 var userPath = os.Getenv("HOME")
 var sdfPath = userPath + "/.config/sdf"
-var baseGit = "git --git-dir=" + sdfPath +
-	" --work-tree=" + userPath
+
+// baseCmd returns a gitcmd.Cmd scoped to the sdf bare repo and $HOME
+// work-tree, ready to have a subcommand attached.
+func baseCmd() *gitcmd.Cmd {
+	return gitcmd.New(sdfPath, userPath)
+}
 
 // sdf add <path>
 // Add files to version control system.
 func addToVCS(paths []string) {
-	fullCmd := append(strings.Fields(baseGit+" add"), paths...)
-	runWithOutput(fullCmd...)
+	cmd, err := baseCmd().WithSubcommand("add").WithPostSepArgs(paths...).Build()
+	check(err)
+	runWithOutput(cmd)
 }
 
 // sdf git <valid git command>
 // Escape the abstractions! Get full access to the underlying repository.
-func delegateCmdToVCS(cmd []string) {
-	fullCmd := append(strings.Fields(baseGit), cmd...)
-	runWithOutput(fullCmd...)
+func delegateCmdToVCS(args []string) {
+	cmd, err := baseCmd().WithSubcommand(args[0]).WithFlags(args[1:]...).Build()
+	check(err)
+	runWithOutput(cmd)
 }
 
 // sdf init <url>
@@ -41,23 +49,26 @@ func initFromVCS(url string) {
 	// Git magic below
 	check(os.MkdirAll(userPath+"/.config", 0600))
 	tempDir := userPath + "/.config/sdf-tmp"
-	runWithOutput(
-		"git", "clone", "--separate-git-dir="+
-			sdfPath, url, tempDir,
-	)
+	cloneCmd, err := (&gitcmd.Cmd{}).
+		WithSubcommand("clone").
+		WithFlags("--separate-git-dir="+sdfPath).
+		WithPostSepArgs(url, tempDir).
+		Build()
+	check(err)
+	runWithOutput(cloneCmd)
 	// ensure git-modules work.
 	modules := tempDir + "/.gitmodules"
 	if _, err := os.Stat(modules); !os.IsNotExist(err) {
 		check(os.Rename(modules, userPath+"/.gitmodules"))
 	}
 	check(os.RemoveAll(tempDir))
-	gitCmd2 := append(
-		strings.Fields(baseGit),
-		"config", "status.showUntrackedFiles", "no",
-	)
-	exec.Command(gitCmd2[0], gitCmd2[1:]...).Run()
+	configCmd, err := baseCmd().WithSubcommand("config").
+		WithFlags("status.showUntrackedFiles", "no").Build()
+	check(err)
+	configCmd.Run()
 	// ensure other users can't see our data.
 	check(os.Chmod(sdfPath, 0700))
+	initSubmodules()
 	fmt.Println("Restored SDF configuration, activate it with 'sdf git checkout .'")
 }
 
@@ -72,21 +83,16 @@ func initNew(url string) {
 		check(os.RemoveAll(sdfPath))
 	}
 	// Git magic below
-	exec.Command(
-		"git", "init", "--bare",
-		sdfPath,
-	).Run()
+	exec.Command("git", "init", "--bare", sdfPath).Run()
 	// This block sets the remote URL
-	gitCmd1 := append(
-		strings.Fields(baseGit),
-		"remote", "add", "master", url,
-	)
-	exec.Command(gitCmd1[0], gitCmd1[1:]...).Run()
-	gitCmd2 := append(
-		strings.Fields(baseGit),
-		"config", "status.showUntrackedFiles", "no",
-	)
-	exec.Command(gitCmd2[0], gitCmd2[1:]...).Run()
+	remoteCmd, err := baseCmd().WithSubcommand("remote").
+		WithFlags("add", "master", url).Build()
+	check(err)
+	remoteCmd.Run()
+	configCmd, err := baseCmd().WithSubcommand("config").
+		WithFlags("status.showUntrackedFiles", "no").Build()
+	check(err)
+	configCmd.Run()
 	// ensure other users can't see our data.
 	check(os.Chmod(sdfPath, 0700))
 	fmt.Println("Initialized new configuration.")
@@ -95,58 +101,77 @@ func initNew(url string) {
 // sdf
 // Show current status.
 func status() {
-	cmd := append(strings.Fields(baseGit), "status")
-	runWithOutput(cmd...)
+	cmd, err := baseCmd().WithSubcommand("status").Build()
+	check(err)
+	runWithOutput(cmd)
 }
 
 // sdf rm <path>
 // Remove a file from the repository.
 func rmFromVCS(paths []string) {
-	fullCmd := append(strings.Fields(baseGit+" rm"), paths...)
-	runWithOutput(fullCmd...)
+	cmd, err := baseCmd().WithSubcommand("rm").WithPostSepArgs(paths...).Build()
+	check(err)
+	runWithOutput(cmd)
 }
 
-// sdf trace <command>
-// Launch the given program under strace and then filters
-// output to display the files that are opened by it.
-func traceCmd(inCmd []string) {
-	// test if strace is present
-	if _, err := exec.LookPath("strace"); err != nil {
-		fmt.Println("Strace not found. Check your $PATH or install it.")
+// sdf trace [--tracer=strace|dtrace|mtime] [--all] [--dry-run] [--filter <glob>] <command...>
+// Launch the given program under a tracer, then present the deduplicated,
+// filtered set of paths it touched under $HOME and stage the ones the user
+// wants via `sdf add`.
+func traceCmd(args []string) {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	tracerName := flags.String("tracer", "", "tracer backend: strace, dtrace, or mtime (default: auto)")
+	all := flags.Bool("all", false, "add every discovered path without prompting")
+	dryRun := flags.Bool("dry-run", false, "print discovered paths, don't stage them")
+	filterGlob := flags.String("filter", "", "only consider paths matching this glob")
+	check(flags.Parse(args))
+	inCmd := flags.Args()
+	if len(inCmd) == 0 {
+		fmt.Println("Please provide command.")
 		return
 	}
-	// test if given binary exist
 	if _, err := exec.LookPath(inCmd[0]); err != nil {
 		fmt.Println("Binary not executable or doesn't exist. Cannot continue.")
 		return
 	}
-	straceArgs := strings.Fields("-f -e trace=openat")
-	fullArgs := append(straceArgs, inCmd...)
-	straceCmd := exec.Command("strace")
-	straceCmd.Args = append(straceCmd.Args, fullArgs...)
-	straceOut, err := straceCmd.StderrPipe()
+
+	t, err := selectTracer(*tracerName)
 	if err != nil {
-		panic(err)
+		fmt.Println(err)
+		return
 	}
-	scanner := bufio.NewReader(straceOut)
-	straceCmd.Start()
-	uplen := len(userPath) // needed for cleaning output
-	for {
-		line, err := scanner.ReadString('\n')
-		if err == io.EOF {
-			break
+	touched, err := t.trace(inCmd)
+	check(err)
+
+	tracked, err := listTrackedFiles()
+	check(err)
+	trackedSet := map[string]bool{}
+	for _, p := range tracked {
+		trackedSet[p] = true
+	}
+	candidates := traceCandidates(touched, trackedSet, loadCombinedIgnoreRules(), *filterGlob)
+
+	if len(candidates) == 0 {
+		fmt.Println("No new paths discovered.")
+		return
+	}
+	if *dryRun {
+		for _, p := range candidates {
+			fmt.Println(p)
 		}
-		temp := strings.Split(line, "\"")
-		if len(temp) > 2 { // make sure line has a valid path
-			if strings.HasPrefix(temp[1], userPath) { // show stuff from $HOME
-				if len(temp[1]) == uplen { // Program viewing home dir; skip
-					continue
-				}
-				fmt.Println(temp[1][uplen+1:]) // remove $HOME prefix
-			}
+		return
+	}
+
+	var toAdd []string
+	for _, p := range candidates {
+		if *all || askForConfirmation("Add "+p+"?") {
+			toAdd = append(toAdd, p)
 		}
 	}
-	straceCmd.Wait() // reap process entry from process table
+	if len(toAdd) == 0 {
+		return
+	}
+	addToVCS(toAdd)
 }
 
 func main() {
@@ -197,6 +222,14 @@ func main() {
 			return
 		}
 		traceCmd(os.Args[2:])
+	case "watch":
+		watchCmd(os.Args[2:])
+	case "submodule":
+		submoduleCmd(os.Args[2:])
+	case "snapshot":
+		snapshotCmd(os.Args[2:])
+	case "diff":
+		diffCmd()
 	default:
 		fmt.Println("Invalid command.")
 		return
@@ -228,10 +261,7 @@ func askForConfirmation(s string) bool {
 	}
 }
 
-func runWithOutput(cmdStr ...string) {
-	cmd := exec.Command(
-		cmdStr[0], cmdStr[1:]...,
-	)
+func runWithOutput(cmd *exec.Cmd) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Run()
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tabulon-ext/sdf/fileset"
+)
+
+func snapshotPath() string { return sdfPath + "/snapshot.json" }
+
+// walkHome builds a FileEntry per file git already tracks (`git ls-files`),
+// reusing the last saved snapshot's size+mtime to skip re-hashing files that
+// haven't changed. Restricting to tracked paths - rather than walking all
+// of $HOME - and skipping unchanged content is what actually makes this
+// cheaper than `git status`; hashing every file under $HOME on every call
+// would not be.
+func walkHome() ([]fileset.FileEntry, error) {
+	tracked, err := listTrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	prev, err := fileset.Load(snapshotPath())
+	if err != nil {
+		return nil, err
+	}
+	return fileset.WalkPaths(userPath, tracked, prev)
+}
+
+// headSnapshot reads the blob shas git already has for HEAD via
+// `git ls-tree -r -z HEAD`, so diff can compare the working tree against
+// the repository's actual state instead of a manually-triggered
+// filesystem snapshot that may not exist yet.
+func headSnapshot() (fileset.Snapshot, error) {
+	cmd, err := baseCmd().WithSubcommand("ls-tree").WithFlags("-r", "-z", "HEAD").Build()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return fileset.Snapshot{}, nil // no HEAD yet (empty repo): nothing to diff against
+	}
+	snap := fileset.Snapshot{}
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\x00"), "\x00") {
+		if line == "" {
+			continue
+		}
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 { // <mode> <type> <sha>
+			continue
+		}
+		snap[path] = fileset.FileEntry{Path: path, Sha1: fields[2]}
+	}
+	return snap, nil
+}
+
+// sdf snapshot save|show|diff
+// save/show record and list a manually-triggered content-hash snapshot
+// of the tracked files under $HOME; diff (see diffCmd) instead compares
+// against HEAD directly.
+func snapshotCmd(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Please provide a snapshot subcommand: save, show, or diff.")
+		return
+	}
+	switch args[0] {
+	case "save":
+		entries, err := walkHome()
+		check(err)
+		check(fileset.Save(snapshotPath(), entries))
+		fmt.Printf("Saved snapshot of %d files.\n", len(entries))
+	case "show":
+		snap, err := fileset.Load(snapshotPath())
+		check(err)
+		printSorted(snapshotPaths(snap))
+	case "diff":
+		diffCmd()
+	default:
+		fmt.Printf("Unknown snapshot subcommand: %q\n", args[0])
+	}
+}
+
+// sdf diff
+// Compare HEAD's blob shas against the working tree without invoking
+// `git status`, which stats every untracked file too and gets slow once
+// $HOME is large. Shorthand for `sdf snapshot diff`.
+func diffCmd() {
+	snap, err := headSnapshot()
+	check(err)
+	entries, err := walkHome()
+	check(err)
+	d := snap.Diff(entries)
+	for _, p := range d.Added {
+		fmt.Println("added:", p)
+	}
+	for _, p := range d.Modified {
+		fmt.Println("modified:", p)
+	}
+	for _, p := range d.Removed {
+		fmt.Println("removed:", p)
+	}
+}
+
+func snapshotPaths(snap fileset.Snapshot) []string {
+	paths := make([]string, 0, len(snap))
+	for p := range snap {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func printSorted(paths []string) {
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestHeadSnapshotReflectsHEAD(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+
+	remote := t.TempDir()
+	sh(t, remote, "init")
+	sh(t, remote, "config", "user.email", "t@example.com")
+	sh(t, remote, "config", "user.name", "t")
+	check0(t, os.WriteFile(filepath.Join(remote, "tracked.txt"), []byte("hello"), 0644))
+	sh(t, remote, "add", "tracked.txt")
+	sh(t, remote, "commit", "-m", "initial")
+
+	oldHome, oldSdf := userPath, sdfPath
+	userPath = t.TempDir()
+	sdfPath = userPath + "/.config/sdf"
+	defer func() { userPath, sdfPath = oldHome, oldSdf }()
+
+	check0(t, os.MkdirAll(userPath+"/.config", 0755))
+	sh(t, userPath, "-c", "protocol.file.allow=always",
+		"clone", "--separate-git-dir="+sdfPath, "file://"+remote, userPath+"/checkout-tmp")
+	check0(t, os.RemoveAll(userPath+"/checkout-tmp"))
+	sh(t, userPath, "--git-dir="+sdfPath, "--work-tree="+userPath, "checkout", "--", "tracked.txt")
+
+	snap, err := headSnapshot()
+	if err != nil {
+		t.Fatalf("headSnapshot: %v", err)
+	}
+	entry, ok := snap["tracked.txt"]
+	if !ok {
+		t.Fatalf("snap = %+v, want an entry for tracked.txt", snap)
+	}
+	if entry.Sha1 == "" {
+		t.Error("entry.Sha1 is empty, want HEAD's blob sha")
+	}
+
+	// Modifying the working copy without touching HEAD should surface as
+	// a diff, not require a manually-triggered `sdf snapshot save` first.
+	check0(t, os.WriteFile(filepath.Join(userPath, "tracked.txt"), []byte("changed"), 0644))
+	entries, err := walkHome()
+	if err != nil {
+		t.Fatalf("walkHome: %v", err)
+	}
+	d := snap.Diff(entries)
+	if len(d.Modified) != 1 || d.Modified[0] != "tracked.txt" {
+		t.Errorf("Modified = %v, want [tracked.txt]", d.Modified)
+	}
+}
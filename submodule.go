@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var submoduleSubcommands = map[string]bool{
+	"add": true, "init": true, "update": true,
+	"foreach": true, "sync": true, "deinit": true,
+}
+
+// sdf submodule <add|init|update|foreach|sync|deinit> [args...]
+// Run the underlying `git submodule` operation with the sdf bare-dir /
+// work-tree context.
+func submoduleCmd(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Please provide a submodule subcommand: add, init, update, foreach, sync, or deinit.")
+		return
+	}
+	if !submoduleSubcommands[args[0]] {
+		fmt.Printf("Unknown submodule subcommand: %q\n", args[0])
+		return
+	}
+	cmd, err := baseCmd().WithSubcommand("submodule").WithFlags(args...).Build()
+	check(err)
+	cmd.Dir = userPath // git-submodule needs a cwd inside the work tree, --work-tree isn't enough
+	runWithOutput(cmd)
+	fixSubmoduleGitlinks()
+}
+
+// initSubmodules runs `submodule update --init --recursive` against the
+// sdf bare repo if a .gitmodules file is present, mirroring the
+// post-clone submodule cascade so users don't end up with a dangling
+// .gitmodules and no checked-out submodules.
+func initSubmodules() {
+	if _, err := os.Stat(userPath + "/.gitmodules"); os.IsNotExist(err) {
+		return
+	}
+	cmd, err := baseCmd().WithSubcommand("submodule").
+		WithFlags("update", "--init", "--recursive").Build()
+	check(err)
+	cmd.Dir = userPath // git-submodule needs a cwd inside the work tree, --work-tree isn't enough
+	runWithOutput(cmd)
+	fixSubmoduleGitlinks()
+}
+
+// fixSubmoduleGitlinks rewrites each submodule's working-tree ".git" file
+// to an absolute gitdir under $sdfPath/modules/<name>. A normal clone can
+// get away with a relative "gitdir: ../.git/modules/<name>" because the
+// work-tree has a real .git next to it; in sdf's bare-dir/worktree split
+// there is no such .git, so the relative path resolves nowhere.
+func fixSubmoduleGitlinks() {
+	cmd, err := baseCmd().WithSubcommand("config").
+		WithFlags("--file", userPath+"/.gitmodules", "--get-regexp", `submodule\..*\.path`).Build()
+	if err != nil {
+		return
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		key := fields[0] // submodule.<name>.path; <name> may itself contain dots
+		if !strings.HasPrefix(key, "submodule.") || !strings.HasSuffix(key, ".path") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "submodule."), ".path")
+		relPath := fields[1]
+		gitFile := filepath.Join(userPath, relPath, ".git")
+		if _, err := os.Stat(gitFile); err != nil {
+			continue
+		}
+		gitDir := filepath.Join(sdfPath, "modules", name)
+		check(os.WriteFile(gitFile, []byte("gitdir: "+gitDir+"\n"), 0644))
+	}
+}
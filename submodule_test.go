@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// sh runs a git command in dir and fails the test on error.
+func sh(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestInitSubmodulesChecksOutNestedSubmodule builds a local file:// remote
+// with one nested submodule, repeats initFromVCS's clone and .gitmodules
+// move by hand, runs initSubmodules, and checks that the submodule's file
+// is checked out.
+func TestInitSubmodulesChecksOutNestedSubmodule(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+	home := t.TempDir()
+
+	subRemote := filepath.Join(home, "sub-remote")
+	check0(t, os.MkdirAll(subRemote, 0755))
+	sh(t, subRemote, "init")
+	sh(t, subRemote, "config", "user.email", "t@example.com")
+	sh(t, subRemote, "config", "user.name", "t")
+	check0(t, os.WriteFile(filepath.Join(subRemote, "f.txt"), []byte("hi"), 0644))
+	sh(t, subRemote, "add", "f.txt")
+	sh(t, subRemote, "commit", "-m", "sub commit")
+
+	mainRemote := filepath.Join(home, "main-remote")
+	check0(t, os.MkdirAll(mainRemote, 0755))
+	sh(t, mainRemote, "init")
+	sh(t, mainRemote, "config", "user.email", "t@example.com")
+	sh(t, mainRemote, "config", "user.name", "t")
+	sh(t, mainRemote, "-c", "protocol.file.allow=always",
+		"submodule", "add", "file://"+subRemote, "vendor/sub")
+	sh(t, mainRemote, "commit", "-m", "add submodule")
+
+	oldHome, oldSdf := userPath, sdfPath
+	userPath = t.TempDir()
+	sdfPath = userPath + "/.config/sdf"
+	defer func() { userPath, sdfPath = oldHome, oldSdf }()
+
+	check0(t, os.MkdirAll(userPath+"/.config", 0755))
+	sh(t, userPath, "-c", "protocol.file.allow=always",
+		"clone", "--separate-git-dir="+sdfPath, "file://"+mainRemote, userPath+"/checkout-tmp")
+	modules := userPath + "/checkout-tmp/.gitmodules"
+	if _, err := os.Stat(modules); err == nil {
+		check0(t, os.Rename(modules, userPath+"/.gitmodules"))
+	}
+	check0(t, os.RemoveAll(userPath+"/checkout-tmp"))
+
+	oldAllow := os.Getenv("GIT_ALLOW_PROTOCOL")
+	os.Setenv("GIT_ALLOW_PROTOCOL", "file")
+	defer os.Setenv("GIT_ALLOW_PROTOCOL", oldAllow)
+
+	initSubmodules()
+
+	marker := filepath.Join(userPath, "vendor/sub/f.txt")
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("expected submodule file to be checked out at %s: %v", marker, err)
+	}
+}
+
+func check0(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tracer watches a child process run to completion and reports the paths
+// under $HOME (relative to it) that it touched: the files it opened, or for
+// mtimeTracer, the files whose mtime changed while it ran.
+type tracer interface {
+	trace(cmdArgs []string) ([]string, error)
+}
+
+// selectTracer picks a tracer backend. name overrides auto-detection;
+// an empty name means "pick whatever works on this platform".
+func selectTracer(name string) (tracer, error) {
+	switch name {
+	case "strace":
+		if _, err := exec.LookPath("strace"); err != nil {
+			return nil, fmt.Errorf("strace not found. Check your $PATH or install it")
+		}
+		return straceTracer{}, nil
+	case "dtrace":
+		if _, err := exec.LookPath("fs_usage"); err != nil {
+			return nil, fmt.Errorf("fs_usage not found. The dtrace backend requires macOS")
+		}
+		return dtraceTracer{}, nil
+	case "mtime":
+		return mtimeTracer{}, nil
+	case "":
+		// fall through to auto-detection below
+	default:
+		return nil, fmt.Errorf("unknown tracer backend: %q", name)
+	}
+	if _, err := exec.LookPath("strace"); err == nil {
+		return straceTracer{}, nil
+	}
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("fs_usage"); err == nil {
+			return dtraceTracer{}, nil
+		}
+	}
+	return mtimeTracer{}, nil
+}
+
+// traceCandidates dedupes a tracer's reported paths, drops anything already
+// tracked or ignored, and - if filterGlob is non-empty - keeps only paths
+// matching it. The result is sorted so traceCmd's prompting order is stable.
+func traceCandidates(touched []string, tracked map[string]bool, ignore ignoreRules, filterGlob string) []string {
+	seen := map[string]bool{}
+	var candidates []string
+	for _, p := range touched {
+		if seen[p] || tracked[p] || ignore.matches(p) {
+			continue
+		}
+		if filterGlob != "" {
+			if ok, _ := filepath.Match(filterGlob, p); !ok {
+				continue
+			}
+		}
+		seen[p] = true
+		candidates = append(candidates, p)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// straceTracer is the original Linux backend: it runs the child under
+// `strace -f -e trace=openat` and scrapes the paths it opens from stderr.
+type straceTracer struct{}
+
+func (straceTracer) trace(cmdArgs []string) ([]string, error) {
+	args := append([]string{"-f", "-e", "trace=openat"}, cmdArgs...)
+	cmd := exec.Command("strace", args...)
+	straceOut, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	var paths []string
+	uplen := len(userPath)
+	scanner := bufio.NewReader(straceOut)
+	for {
+		line, err := scanner.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		temp := strings.Split(line, "\"")
+		if len(temp) > 2 && strings.HasPrefix(temp[1], userPath) && len(temp[1]) != uplen {
+			paths = append(paths, temp[1][uplen+1:])
+		}
+	}
+	cmd.Wait() // reap process entry from process table
+	return paths, nil
+}
+
+// dtraceTracer is the macOS backend: it shells out to `fs_usage -w -f
+// filesys <pid>` (the dtrace-backed syscall tracer shipped with macOS)
+// while the child runs, and scrapes the paths it touches under $HOME.
+type dtraceTracer struct{}
+
+func (dtraceTracer) trace(cmdArgs []string) ([]string, error) {
+	child := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	if err := child.Start(); err != nil {
+		return nil, err
+	}
+	pid := fmt.Sprintf("%d", child.Process.Pid)
+	fsUsage := exec.Command("sudo", "fs_usage", "-w", "-f", "filesys", pid)
+	out, err := fsUsage.StdoutPipe()
+	if err != nil {
+		child.Wait()
+		return nil, err
+	}
+	if err := fsUsage.Start(); err != nil {
+		child.Wait()
+		return nil, err
+	}
+
+	uplen := len(userPath)
+	seen := map[string]bool{}
+	var paths []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewReader(out)
+		for {
+			line, err := scanner.ReadString('\n')
+			if err == io.EOF {
+				return
+			}
+			for _, field := range strings.Fields(line) {
+				if strings.HasPrefix(field, userPath) && len(field) > uplen && !seen[field] {
+					seen[field] = true
+					paths = append(paths, field[uplen+1:])
+				}
+			}
+		}
+	}()
+
+	child.Wait()
+	fsUsage.Process.Kill()
+	<-done
+	return paths, nil
+}
+
+// mtimeTracer is the fallback backend for platforms with no syscall
+// tracer available: it snapshots mtimes of $HOME before and after the
+// child runs and reports whatever changed.
+type mtimeTracer struct{}
+
+func (mtimeTracer) trace(cmdArgs []string) ([]string, error) {
+	before := snapshotMtimes(userPath)
+	child := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Stdin = os.Stdin
+	if err := child.Run(); err != nil {
+		return nil, err
+	}
+	after := snapshotMtimes(userPath)
+	var changed []string
+	for rel, mtime := range after {
+		if prev, ok := before[rel]; !ok || !prev.Equal(mtime) {
+			changed = append(changed, rel)
+		}
+	}
+	return changed, nil
+}
+
+func snapshotMtimes(root string) map[string]time.Time {
+	snap := map[string]time.Time{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		snap[rel] = info.ModTime()
+		return nil
+	})
+	return snap
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMtimeTracerTrace(t *testing.T) {
+	oldHome := userPath
+	userPath = t.TempDir()
+	defer func() { userPath = oldHome }()
+
+	if err := os.WriteFile(userPath+"/untouched.txt", []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := mtimeTracer{}
+	touched, err := tr.trace([]string{"touch", userPath + "/touched.txt"})
+	if err != nil {
+		t.Fatalf("trace: %v", err)
+	}
+	if len(touched) != 1 || touched[0] != "touched.txt" {
+		t.Errorf("touched = %v, want [touched.txt]", touched)
+	}
+}
+
+func TestMtimeTracerTraceReportsModifiedFile(t *testing.T) {
+	oldHome := userPath
+	userPath = t.TempDir()
+	defer func() { userPath = oldHome }()
+
+	path := userPath + "/existing.txt"
+	if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Force the mtime back so the touch below is guaranteed to move it
+	// forward even on filesystems with coarse mtime resolution.
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := mtimeTracer{}
+	touched, err := tr.trace([]string{"touch", path})
+	if err != nil {
+		t.Fatalf("trace: %v", err)
+	}
+	if len(touched) != 1 || touched[0] != "existing.txt" {
+		t.Errorf("touched = %v, want [existing.txt]", touched)
+	}
+}
+
+func TestTraceCandidatesFiltersTrackedAndIgnored(t *testing.T) {
+	tracked := map[string]bool{"already-tracked.txt": true}
+	ignore := ignoreRules{patterns: []string{"*.log"}}
+	touched := []string{"new.txt", "already-tracked.txt", "debug.log", "new.txt", "sub/new.txt"}
+
+	got := traceCandidates(touched, tracked, ignore, "")
+	want := []string{"new.txt", "sub/new.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("traceCandidates = %v, want %v", got, want)
+	}
+}
+
+func TestTraceCandidatesAppliesFilterGlob(t *testing.T) {
+	tracked := map[string]bool{}
+	ignore := ignoreRules{}
+	touched := []string{"a.txt", "b.md", "c.txt"}
+
+	got := traceCandidates(touched, tracked, ignore, "*.txt")
+	want := []string{"a.txt", "c.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("traceCandidates = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/tabulon-ext/sdf/fileset"
+)
+
+const defaultDebounce = 2 * time.Second
+
+func watchStatePath() string {
+	return sdfPath + "/watch-state.json"
+}
+
+// listTrackedFiles returns every path the bare repo already tracks, relative
+// to $HOME, via `git ls-files -z` (NUL-separated so spaces in paths survive).
+func listTrackedFiles() ([]string, error) {
+	cmd, err := baseCmd().WithSubcommand("ls-files").WithFlags("-z").Build()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(strings.TrimRight(string(out), "\x00"), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// sdf watch [--push] [--dry-run] [--debounce 2s]
+// Watch every path already tracked by the bare repo and auto-commit (and
+// optionally auto-push) batches of changes once they've settled for a
+// debounce window.
+func watchCmd(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	push := flags.Bool("push", false, "push after each auto-commit")
+	dryRun := flags.Bool("dry-run", false, "print what would be committed, don't touch git")
+	debounce := flags.Duration("debounce", defaultDebounce, "quiet period before a snapshot runs")
+	check(flags.Parse(args))
+
+	tracked, err := listTrackedFiles()
+	check(err)
+	trackedSet := map[string]bool{}
+	watchedDirs := map[string]bool{}
+
+	watcher, err := fsnotify.NewWatcher()
+	check(err)
+	defer watcher.Close()
+
+	for _, path := range tracked {
+		trackedSet[path] = true
+		dir := filepath.Dir(filepath.Join(userPath, path))
+		if !watchedDirs[dir] {
+			if err := watcher.Add(dir); err == nil {
+				watchedDirs[dir] = true
+			}
+		}
+	}
+
+	state, err := fileset.Load(watchStatePath())
+	check(err)
+
+	// mu guards dirty, timer, and state: runSnapshot mutates state and runs
+	// on whatever goroutine time.AfterFunc fires on, so without it a fs
+	// event landing mid-commit would race a concurrent map read/write.
+	var mu sync.Mutex
+	dirty := map[string]bool{}
+	var timer *time.Timer
+	fire := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		batch := dirty
+		dirty = map[string]bool{}
+		runSnapshot(trackedSet, state, batch, *push, *dryRun)
+	}
+
+	fmt.Printf("sdf watch: watching %d tracked files (debounce %s)\n", len(tracked), *debounce)
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if !watchedDirs[event.Name] {
+						if err := watcher.Add(event.Name); err == nil {
+							watchedDirs[event.Name] = true
+						}
+					}
+					continue
+				}
+			}
+			rel, err := filepath.Rel(userPath, event.Name)
+			if err != nil || !trackedSet[rel] {
+				continue
+			}
+			mu.Lock()
+			dirty[rel] = true
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(*debounce, fire)
+			mu.Unlock()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Fprintln(os.Stderr, "sdf watch:", err)
+		}
+	}
+}
+
+// runSnapshot re-hashes the dirty files, skips the ones whose content
+// matches what's already recorded, and commits (optionally pushes) the rest.
+func runSnapshot(tracked map[string]bool, state fileset.Snapshot, dirty map[string]bool, push, dryRun bool) {
+	var changed, removed []string
+	for rel := range dirty {
+		full := filepath.Join(userPath, rel)
+		sha, err := fileset.BlobSha1(full)
+		if err != nil {
+			if os.IsNotExist(err) {
+				removed = append(removed, rel)
+				delete(state, rel)
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "sdf watch:", err)
+			continue
+		}
+		if prev, ok := state[rel]; ok && prev.Sha1 == sha {
+			continue
+		}
+		info, err := os.Stat(full)
+		if err != nil {
+			continue
+		}
+		state[rel] = fileset.FileEntry{Path: rel, Size: info.Size(), Mtime: info.ModTime(), Sha1: sha}
+		changed = append(changed, rel)
+	}
+
+	if len(changed) == 0 && len(removed) == 0 {
+		return
+	}
+	total := len(changed) + len(removed)
+	if dryRun {
+		fmt.Printf("sdf watch (dry-run): would commit %d file(s)\n", total)
+		for _, rel := range changed {
+			fmt.Println("  modified:", rel)
+		}
+		for _, rel := range removed {
+			fmt.Println("  removed:", rel)
+		}
+		return
+	}
+
+	if len(changed) > 0 {
+		addCmd, err := baseCmd().WithSubcommand("add").WithPostSepArgs(changed...).Build()
+		check(err)
+		addCmd.Run()
+	}
+	if len(removed) > 0 {
+		rmCmd, err := baseCmd().WithSubcommand("rm").WithFlags("--ignore-unmatch").
+			WithPostSepArgs(removed...).Build()
+		check(err)
+		rmCmd.Run()
+	}
+	// --message=<text> keeps the whole commit message in one argv token,
+	// so it can never be split across "-m" and a following argument the
+	// way a bare "-m" + separate value could (gitcmd's "--" separator
+	// only protects trailing positional args, not an option's own value).
+	commitCmd, err := baseCmd().WithSubcommand("commit").
+		WithFlags("--message=" + fmt.Sprintf("sdf watch: %d files", total)).Build()
+	check(err)
+	runWithOutput(commitCmd)
+	entries := make([]fileset.FileEntry, 0, len(state))
+	for _, e := range state {
+		entries = append(entries, e)
+	}
+	check(fileset.Save(watchStatePath(), entries))
+
+	if push {
+		pushCmd, err := baseCmd().WithSubcommand("push").Build()
+		if err != nil {
+			return
+		}
+		runWithOutput(pushCmd)
+	}
+}